@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// ringBuffer ist eine feste, zirkuläre Warteschlange für SystemStats-Proben,
+// die gesendet werden sollen, sobald die API wieder erreichbar ist. Ist sie
+// voll, wird die älteste Probe verworfen statt die neueste zu blockieren.
+type ringBuffer struct {
+	mu    sync.Mutex
+	items []SystemStats
+	head  int
+	count int
+}
+
+func newRingBuffer(capacity int) *ringBuffer {
+	return &ringBuffer{items: make([]SystemStats, capacity)}
+}
+
+// push legt stats ab und meldet via dropped, ob dafür die älteste Probe
+// verworfen werden musste.
+func (r *ringBuffer) push(stats SystemStats) (dropped bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	capacity := len(r.items)
+	if capacity == 0 {
+		return true
+	}
+
+	if r.count == capacity {
+		r.head = (r.head + 1) % capacity
+		dropped = true
+	} else {
+		r.count++
+	}
+
+	idx := (r.head + r.count - 1) % capacity
+	r.items[idx] = stats
+	return dropped
+}
+
+// popBatch entfernt bis zu n Proben vom Kopf der Queue (FIFO).
+func (r *ringBuffer) popBatch(n int) []SystemStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.popBatchLocked(n)
+}
+
+func (r *ringBuffer) popBatchLocked(n int) []SystemStats {
+	if r.count == 0 {
+		return nil
+	}
+	if n > r.count {
+		n = r.count
+	}
+
+	capacity := len(r.items)
+	batch := make([]SystemStats, n)
+	for i := 0; i < n; i++ {
+		batch[i] = r.items[(r.head+i)%capacity]
+	}
+	r.head = (r.head + n) % capacity
+	r.count -= n
+	return batch
+}
+
+// pushFront stellt eine zuvor entnommene, aber nicht erfolgreich versendete
+// Batch wieder an den Kopf der Queue, damit die FIFO-Reihenfolge erhalten
+// bleibt. Proben, für die kein Platz mehr ist, werden verworfen.
+func (r *ringBuffer) pushFront(batch []SystemStats) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	capacity := len(r.items)
+	if capacity == 0 {
+		return
+	}
+
+	for i := len(batch) - 1; i >= 0; i-- {
+		if r.count == capacity {
+			break
+		}
+		r.head = (r.head - 1 + capacity) % capacity
+		r.items[r.head] = batch[i]
+		r.count++
+	}
+}
+
+// drain entnimmt alle derzeit gepufferten Proben.
+func (r *ringBuffer) drain() []SystemStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.popBatchLocked(r.count)
+}
+
+func (r *ringBuffer) len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.count
+}
+
+// wal ist ein Append-only-JSON-Lines-Log, das beim Shutdown die noch im
+// ringBuffer wartenden Proben aufnimmt und beim nächsten Start wieder
+// eingespielt wird, damit ein Neustart keine gepufferten Daten verliert.
+// Eingespielt wird nur die aktuelle Datei unter path; durch Rotation
+// umbenannte Dateien bleiben liegen und werden von replay nicht gelesen.
+type wal struct {
+	mu       sync.Mutex
+	path     string
+	maxBytes int64
+	file     *os.File
+}
+
+// openWAL öffnet (oder erstellt) die WAL-Datei unter path. Ein leerer path
+// deaktiviert die On-Disk-Pufferung; das Programm arbeitet dann rein
+// speicherbasiert.
+func openWAL(path string, maxBytes int64) (*wal, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open WAL: %w", err)
+	}
+
+	return &wal{path: path, maxBytes: maxBytes, file: f}, nil
+}
+
+// append hängt jede Probe als eigene JSON-Zeile an und rotiert die Datei
+// danach bei Bedarf.
+func (w *wal) append(items []SystemStats) error {
+	if w == nil || len(items) == 0 {
+		return nil
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, item := range items {
+		line, err := json.Marshal(item)
+		if err != nil {
+			return fmt.Errorf("marshal WAL entry: %w", err)
+		}
+		if _, err := w.file.Write(append(line, '\n')); err != nil {
+			return fmt.Errorf("write WAL entry: %w", err)
+		}
+	}
+
+	return w.rotateIfNeededLocked()
+}
+
+// rotateIfNeededLocked benennt die WAL-Datei um, sobald sie maxBytes
+// überschreitet, und beginnt eine neue leere Datei.
+func (w *wal) rotateIfNeededLocked() error {
+	if w.maxBytes <= 0 {
+		return nil
+	}
+
+	info, err := w.file.Stat()
+	if err != nil {
+		return fmt.Errorf("stat WAL: %w", err)
+	}
+	if info.Size() < w.maxBytes {
+		return nil
+	}
+
+	if err := w.file.Close(); err != nil {
+		return fmt.Errorf("close WAL before rotate: %w", err)
+	}
+
+	rotated := fmt.Sprintf("%s.%d", w.path, time.Now().UnixNano())
+	if err := os.Rename(w.path, rotated); err != nil {
+		return fmt.Errorf("rotate WAL: %w", err)
+	}
+
+	f, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("reopen WAL after rotate: %w", err)
+	}
+	w.file = f
+	return nil
+}
+
+// replay liest alle Proben aus der aktuellen WAL-Datei und leert sie danach,
+// damit sie beim nächsten Shutdown nicht doppelt angehängt werden.
+func (w *wal) replay() ([]SystemStats, error) {
+	if w == nil {
+		return nil, nil
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	f, err := os.Open(w.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("open WAL for replay: %w", err)
+	}
+
+	var items []SystemStats
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var item SystemStats
+		if err := json.Unmarshal(line, &item); err != nil {
+			log.Printf("skipping corrupt WAL entry: %v", err)
+			continue
+		}
+		items = append(items, item)
+	}
+	f.Close()
+	if err := scanner.Err(); err != nil {
+		return items, fmt.Errorf("scan WAL: %w", err)
+	}
+
+	if err := w.file.Close(); err != nil {
+		return items, fmt.Errorf("close WAL before truncate: %w", err)
+	}
+	reopened, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return items, fmt.Errorf("truncate WAL after replay: %w", err)
+	}
+	w.file = reopened
+
+	return items, nil
+}
+
+func (w *wal) close() error {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.file.Close()
+}
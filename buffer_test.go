@@ -0,0 +1,149 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRingBufferFIFOAndDropOldest(t *testing.T) {
+	rb := newRingBuffer(3)
+
+	for i := 0; i < 3; i++ {
+		if dropped := rb.push(SystemStats{Hostname: string(rune('a' + i))}); dropped {
+			t.Fatalf("push %d: unexpected drop", i)
+		}
+	}
+
+	if dropped := rb.push(SystemStats{Hostname: "d"}); !dropped {
+		t.Fatal("push into full buffer should report dropped oldest")
+	}
+
+	batch := rb.popBatch(10)
+	var hostnames string
+	for _, s := range batch {
+		hostnames += s.Hostname
+	}
+	if hostnames != "bcd" {
+		t.Fatalf("expected FIFO order bcd after drop-oldest, got %q", hostnames)
+	}
+}
+
+func TestRingBufferPushFrontPreservesOrder(t *testing.T) {
+	rb := newRingBuffer(5)
+	rb.push(SystemStats{Hostname: "a"})
+	rb.push(SystemStats{Hostname: "b"})
+
+	batch := rb.popBatch(2)
+	rb.pushFront(batch)
+
+	rb.push(SystemStats{Hostname: "c"})
+
+	got := rb.popBatch(10)
+	var hostnames string
+	for _, s := range got {
+		hostnames += s.Hostname
+	}
+	if hostnames != "abc" {
+		t.Fatalf("expected abc after pushFront, got %q", hostnames)
+	}
+}
+
+func TestRingBufferDrain(t *testing.T) {
+	rb := newRingBuffer(2)
+	rb.push(SystemStats{Hostname: "a"})
+	rb.push(SystemStats{Hostname: "b"})
+
+	drained := rb.drain()
+	if len(drained) != 2 {
+		t.Fatalf("expected 2 drained samples, got %d", len(drained))
+	}
+	if rb.len() != 0 {
+		t.Fatalf("expected empty buffer after drain, got len %d", rb.len())
+	}
+}
+
+func TestWALRotatesWhenOverMaxBytes(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "wal.jsonl")
+
+	w, err := openWAL(path, 1)
+	if err != nil {
+		t.Fatalf("openWAL: %v", err)
+	}
+
+	if err := w.append([]SystemStats{{Hostname: "a"}}); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("read dir: %v", err)
+	}
+	if len(entries) < 2 {
+		t.Fatalf("expected rotation to leave a rotated file alongside the active WAL, got %d files", len(entries))
+	}
+
+	if err := w.close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+}
+
+func TestWALReplayTruncatesAfterRead(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "wal.jsonl")
+
+	w, err := openWAL(path, 0)
+	if err != nil {
+		t.Fatalf("openWAL: %v", err)
+	}
+	if err := w.append([]SystemStats{{Hostname: "a"}, {Hostname: "b"}}); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+
+	replayed, err := w.replay()
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if len(replayed) != 2 {
+		t.Fatalf("expected 2 replayed entries, got %d", len(replayed))
+	}
+
+	again, err := w.replay()
+	if err != nil {
+		t.Fatalf("second replay: %v", err)
+	}
+	if len(again) != 0 {
+		t.Fatalf("expected WAL to be empty after truncate, got %d entries", len(again))
+	}
+
+	if err := w.close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+}
+
+func TestBackoffDelayBounds(t *testing.T) {
+	for attempt := 0; attempt < 10; attempt++ {
+		d := backoffDelay(attempt)
+		if d <= 0 {
+			t.Fatalf("attempt %d: backoffDelay returned non-positive duration %v", attempt, d)
+		}
+		if d > backoffCap {
+			t.Fatalf("attempt %d: backoffDelay %v exceeds cap %v", attempt, d, backoffCap)
+		}
+	}
+}
+
+func TestBackoffDelayClampsLargeAttempts(t *testing.T) {
+	d := backoffDelay(100)
+	if d <= 0 || d > backoffCap {
+		t.Fatalf("backoffDelay(100) = %v, want in (0, %v]", d, backoffCap)
+	}
+}
+
+func TestBackoffDelayIsWithinBaseShiftBeforeCap(t *testing.T) {
+	d := backoffDelay(0)
+	if d > backoffBase {
+		t.Fatalf("backoffDelay(0) = %v, want <= backoffBase %v", d, backoffBase)
+	}
+}
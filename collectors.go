@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/disk"
+	"github.com/shirou/gopsutil/v3/load"
+	"github.com/shirou/gopsutil/v3/net"
+)
+
+// Collector liefert einen benannten Ausschnitt der Systemstatistiken.
+// Collect darf teuer sein (z.B. ein Sampling-Intervall); der Aufrufer
+// übergibt dafür einen Context mit Deadline.
+type Collector interface {
+	Name() string
+	Collect(ctx context.Context) (any, error)
+}
+
+// CPUCoreInfo beschreibt einen einzelnen logischen CPU-Kern.
+type CPUCoreInfo struct {
+	VendorID string  `json:"vendor_id"`
+	Family   string  `json:"family"`
+	Model    string  `json:"model"`
+	MHz      float64 `json:"mhz"`
+}
+
+// CPUStats fasst die Ausgabe des CPU-Collectors zusammen.
+type CPUStats struct {
+	PercentPerCore []float64     `json:"percent_per_core"`
+	Cores          []CPUCoreInfo `json:"cores"`
+}
+
+type cpuCollector struct{}
+
+func newCPUCollector() *cpuCollector { return &cpuCollector{} }
+
+func (c *cpuCollector) Name() string { return "cpu" }
+
+func (c *cpuCollector) Collect(ctx context.Context) (any, error) {
+	percentages, err := cpu.PercentWithContext(ctx, 0, true)
+	if err != nil {
+		return nil, fmt.Errorf("cpu percent: %w", err)
+	}
+
+	info, err := cpu.InfoWithContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cpu info: %w", err)
+	}
+
+	cores := make([]CPUCoreInfo, 0, len(info))
+	for _, i := range info {
+		cores = append(cores, CPUCoreInfo{
+			VendorID: i.VendorID,
+			Family:   i.Family,
+			Model:    i.Model,
+			MHz:      i.Mhz,
+		})
+	}
+
+	return CPUStats{PercentPerCore: percentages, Cores: cores}, nil
+}
+
+// DiskStats beschreibt die Auslastung eines gemounteten Dateisystems.
+type DiskStats struct {
+	Device      string  `json:"device"`
+	Mountpoint  string  `json:"mountpoint"`
+	Fstype      string  `json:"fstype"`
+	TotalBytes  uint64  `json:"total_bytes"`
+	UsedBytes   uint64  `json:"used_bytes"`
+	FreeBytes   uint64  `json:"free_bytes"`
+	UsedPercent float64 `json:"used_percent"`
+}
+
+type diskCollector struct{}
+
+func newDiskCollector() *diskCollector { return &diskCollector{} }
+
+func (c *diskCollector) Name() string { return "disk" }
+
+func (c *diskCollector) Collect(ctx context.Context) (any, error) {
+	partitions, err := disk.PartitionsWithContext(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("disk partitions: %w", err)
+	}
+
+	stats := make([]DiskStats, 0, len(partitions))
+	for _, p := range partitions {
+		usage, err := disk.UsageWithContext(ctx, p.Mountpoint)
+		if err != nil {
+			// Ein nicht lesbares Mount (z.B. entfernte Wechseldatenträger) soll
+			// die restlichen Mounts nicht mit zu Fall bringen.
+			continue
+		}
+
+		stats = append(stats, DiskStats{
+			Device:      p.Device,
+			Mountpoint:  p.Mountpoint,
+			Fstype:      p.Fstype,
+			TotalBytes:  usage.Total,
+			UsedBytes:   usage.Used,
+			FreeBytes:   usage.Free,
+			UsedPercent: usage.UsedPercent,
+		})
+	}
+
+	return stats, nil
+}
+
+// NetStats beschreibt die kumulierten Bytes/Pakete einer Netzwerkschnittstelle
+// sowie die seit der letzten Collect-Runde übertragenen Bytes.
+type NetStats struct {
+	Interface   string `json:"interface"`
+	BytesSent   uint64 `json:"bytes_sent"`
+	BytesRecv   uint64 `json:"bytes_recv"`
+	PacketsSent uint64 `json:"packets_sent"`
+	PacketsRecv uint64 `json:"packets_recv"`
+	DeltaSent   uint64 `json:"delta_bytes_sent"`
+	DeltaRecv   uint64 `json:"delta_bytes_recv"`
+}
+
+// netCollector hält die zuletzt gesehenen Zählerstände vor, um Deltas
+// zwischen zwei Collect-Aufrufen auszurechnen.
+type netCollector struct {
+	last map[string]net.IOCountersStat
+}
+
+func newNetCollector() *netCollector {
+	return &netCollector{last: make(map[string]net.IOCountersStat)}
+}
+
+func (c *netCollector) Name() string { return "network" }
+
+func (c *netCollector) Collect(ctx context.Context) (any, error) {
+	counters, err := net.IOCountersWithContext(ctx, true)
+	if err != nil {
+		return nil, fmt.Errorf("net io counters: %w", err)
+	}
+
+	stats := make([]NetStats, 0, len(counters))
+	for _, cnt := range counters {
+		prev, ok := c.last[cnt.Name]
+
+		s := NetStats{
+			Interface:   cnt.Name,
+			BytesSent:   cnt.BytesSent,
+			BytesRecv:   cnt.BytesRecv,
+			PacketsSent: cnt.PacketsSent,
+			PacketsRecv: cnt.PacketsRecv,
+		}
+		if ok {
+			s.DeltaSent = cnt.BytesSent - prev.BytesSent
+			s.DeltaRecv = cnt.BytesRecv - prev.BytesRecv
+		}
+
+		stats = append(stats, s)
+		c.last[cnt.Name] = cnt
+	}
+
+	return stats, nil
+}
+
+// LoadStats sind die klassischen 1/5/15-Minuten-Lastmittelwerte.
+type LoadStats struct {
+	Load1  float64 `json:"load1"`
+	Load5  float64 `json:"load5"`
+	Load15 float64 `json:"load15"`
+}
+
+type loadCollector struct{}
+
+func newLoadCollector() *loadCollector { return &loadCollector{} }
+
+func (c *loadCollector) Name() string { return "load" }
+
+func (c *loadCollector) Collect(ctx context.Context) (any, error) {
+	avg, err := load.AvgWithContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load avg: %w", err)
+	}
+
+	return LoadStats{Load1: avg.Load1, Load5: avg.Load5, Load15: avg.Load15}, nil
+}
+
+// ThermalZoneStats ist die Temperatur einer einzelnen /sys/class/thermal-Zone.
+type ThermalZoneStats struct {
+	Zone         string  `json:"zone"`
+	Type         string  `json:"type"`
+	CelsiusValue float64 `json:"celsius"`
+}
+
+type thermalCollector struct {
+	zonesGlob string
+	// zones beschränkt die Sammlung auf bestimmte Zonennamen (z.B.
+	// "thermal_zone0"). Ein leeres zones sammelt alle gefundenen Zonen.
+	zones []string
+}
+
+func newThermalCollector(zones []string) *thermalCollector {
+	return &thermalCollector{zonesGlob: "/sys/class/thermal", zones: zones}
+}
+
+func (c *thermalCollector) Name() string { return "thermal" }
+
+func (c *thermalCollector) wanted(zone string) bool {
+	if len(c.zones) == 0 {
+		return true
+	}
+	for _, z := range c.zones {
+		if z == zone {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *thermalCollector) Collect(ctx context.Context) (any, error) {
+	entries, err := ioutil.ReadDir(c.zonesGlob)
+	if err != nil {
+		return nil, fmt.Errorf("thermal zones: %w", err)
+	}
+
+	var zones []ThermalZoneStats
+	for _, e := range entries {
+		if !strings.HasPrefix(e.Name(), "thermal_zone") || !c.wanted(e.Name()) {
+			continue
+		}
+
+		base := c.zonesGlob + "/" + e.Name()
+
+		tempRaw, err := ioutil.ReadFile(base + "/temp")
+		if err != nil {
+			continue
+		}
+		tempMilliC, err := parseFloat(strings.TrimSpace(string(tempRaw)))
+		if err != nil {
+			continue
+		}
+
+		zoneType := ""
+		if typeRaw, err := ioutil.ReadFile(base + "/type"); err == nil {
+			zoneType = strings.TrimSpace(string(typeRaw))
+		}
+
+		zones = append(zones, ThermalZoneStats{
+			Zone:         e.Name(),
+			Type:         zoneType,
+			CelsiusValue: tempMilliC / 1000,
+		})
+	}
+
+	return zones, nil
+}
@@ -0,0 +1,354 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// Config ist die vollständig aufgelöste Konfiguration des Agents, nachdem
+// defaults, eine optionale Config-Datei, Umgebungsvariablen und Flags in
+// dieser Reihenfolge (niedrigste zu höchste Priorität) angewendet wurden.
+type Config struct {
+	APIURL            string
+	APISecret         string
+	CollectInterval   time.Duration
+	RequestTimeout    time.Duration
+	ThermalZones      []string
+	EnabledCollectors []string
+	Sinks             []string
+	HostnameOverride  string
+	Labels            map[string]string
+
+	HTTPPath          string
+	HTTPAuthMode      string
+	HTTPHeaders       map[string]string
+	BufferCapacity    int
+	BufferPath        string
+	BufferWALMaxBytes int64
+	SendBatchSize     int
+	PromListen        string
+
+	ConfigPath  string
+	PrintConfig bool
+}
+
+// defaultBufferCapacity und defaultSendBatchSize sind auch das Fallback, auf
+// das validateSinkConfig zurückfällt, wenn eine Datei- oder Env-Quelle einen
+// nicht-positiven Wert liefert.
+const (
+	defaultBufferCapacity = 1024
+	defaultSendBatchSize  = 20
+)
+
+// defaultConfig liefert die eingebauten Vorgabewerte, bevor Datei, Umgebung
+// oder Flags angewendet werden.
+func defaultConfig() Config {
+	return Config{
+		CollectInterval:   5 * time.Second,
+		RequestTimeout:    10 * time.Second,
+		EnabledCollectors: append([]string(nil), defaultCollectorNames...),
+		Sinks:             append([]string(nil), defaultSinkNames...),
+
+		BufferCapacity:    defaultBufferCapacity,
+		BufferWALMaxBytes: 10 * 1024 * 1024,
+		SendBatchSize:     defaultSendBatchSize,
+		PromListen:        ":9256",
+	}
+}
+
+// fileConfig bildet das Schema einer YAML- oder TOML-Config-Datei ab. Felder
+// sind optional (Zeiger bzw. nil-Slices/-Maps), damit nur tatsächlich
+// gesetzte Werte die vorherige Stufe überschreiben.
+type fileConfig struct {
+	APIURL            *string           `yaml:"api_url" toml:"api_url"`
+	APISecret         *string           `yaml:"api_secret" toml:"api_secret"`
+	CollectIntervalS  *int              `yaml:"collect_interval_seconds" toml:"collect_interval_seconds"`
+	RequestTimeoutS   *int              `yaml:"request_timeout_seconds" toml:"request_timeout_seconds"`
+	ThermalZones      []string          `yaml:"thermal_zones" toml:"thermal_zones"`
+	EnabledCollectors []string          `yaml:"enabled_collectors" toml:"enabled_collectors"`
+	Sinks             []string          `yaml:"sinks" toml:"sinks"`
+	HostnameOverride  *string           `yaml:"hostname_override" toml:"hostname_override"`
+	Labels            map[string]string `yaml:"labels" toml:"labels"`
+
+	HTTPPath          *string           `yaml:"http_path" toml:"http_path"`
+	HTTPAuthMode      *string           `yaml:"http_auth_mode" toml:"http_auth_mode"`
+	HTTPHeaders       map[string]string `yaml:"http_headers" toml:"http_headers"`
+	BufferCapacity    *int              `yaml:"buffer_capacity" toml:"buffer_capacity"`
+	BufferPath        *string           `yaml:"buffer_path" toml:"buffer_path"`
+	BufferWALMaxBytes *int64            `yaml:"buffer_wal_max_bytes" toml:"buffer_wal_max_bytes"`
+	SendBatchSize     *int              `yaml:"send_batch_size" toml:"send_batch_size"`
+	PromListen        *string           `yaml:"prom_listen" toml:"prom_listen"`
+}
+
+// loadConfig baut die effektive Config aus defaults, einer optionalen
+// Config-Datei (YAML oder TOML, per --config/AGENT_CONFIG), Umgebungsvariablen
+// und Flags auf. args sind die Programmargumente ohne den Programmnamen
+// (os.Args[1:]).
+func loadConfig(args []string) (Config, error) {
+	cfg := defaultConfig()
+
+	// .env bleibt optional: fehlt sie, ist das nur eine Warnung, damit der
+	// Agent auch unter systemd/Docker sauber startet, wo die Variablen schon
+	// aus der Umgebung kommen.
+	if err := godotenv.Load(); err != nil {
+		log.Printf("no .env file loaded: %v", err)
+	}
+
+	fs := flag.NewFlagSet("agent", flag.ContinueOnError)
+	flagConfigPath := fs.String("config", os.Getenv("AGENT_CONFIG"), "path to a YAML or TOML config file")
+	flagAPIURL := fs.String("api-url", "", "API URL")
+	flagAPISecret := fs.String("api-secret", "", "API secret/token")
+	flagCollectInterval := fs.Int("collect-interval", 0, "collection interval in seconds")
+	flagRequestTimeout := fs.Int("request-timeout", 0, "per-request/per-collector timeout in seconds")
+	flagEnabledCollectors := fs.String("enabled-collectors", "", "comma-separated list of collectors")
+	flagSinks := fs.String("sinks", "", "comma-separated list of sinks")
+	flagHostnameOverride := fs.String("hostname-override", "", "override the hostname reported with each sample")
+	flagHTTPPath := fs.String("http-path", "", "HTTP sink request path (default /api/agent)")
+	flagHTTPAuthMode := fs.String("http-auth-mode", "", "HTTP sink auth mode: raw, bearer or hmac")
+	flagHTTPHeaders := fs.String("http-headers", "", "comma-separated Key=Value HTTP sink headers")
+	flagBufferCapacity := fs.Int("buffer-capacity", 0, "offline ring buffer capacity (samples)")
+	flagBufferPath := fs.String("buffer-path", "", "WAL file path for offline buffering")
+	flagBufferWALMaxBytes := fs.Int64("buffer-wal-max-bytes", 0, "WAL rotation threshold in bytes")
+	flagSendBatchSize := fs.Int("send-batch-size", 0, "max samples per buffered-retry batch")
+	flagPromListen := fs.String("prom-listen", "", "listen address for the Prometheus sink")
+	flagPrintConfig := fs.Bool("print-config", false, "print the resolved effective config as JSON and exit")
+	if err := fs.Parse(args); err != nil {
+		return cfg, err
+	}
+
+	cfg.ConfigPath = *flagConfigPath
+	if cfg.ConfigPath != "" {
+		fc, err := loadFileConfig(cfg.ConfigPath)
+		if err != nil {
+			return cfg, fmt.Errorf("load config file: %w", err)
+		}
+		applyFileConfig(&cfg, fc)
+	}
+
+	applyEnv(&cfg)
+
+	if *flagAPIURL != "" {
+		cfg.APIURL = *flagAPIURL
+	}
+	if *flagAPISecret != "" {
+		cfg.APISecret = *flagAPISecret
+	}
+	if *flagCollectInterval > 0 {
+		cfg.CollectInterval = time.Duration(*flagCollectInterval) * time.Second
+	}
+	if *flagRequestTimeout > 0 {
+		cfg.RequestTimeout = time.Duration(*flagRequestTimeout) * time.Second
+	}
+	if *flagEnabledCollectors != "" {
+		cfg.EnabledCollectors = splitAndTrim(*flagEnabledCollectors)
+	}
+	if *flagSinks != "" {
+		cfg.Sinks = splitAndTrim(*flagSinks)
+	}
+	if *flagHostnameOverride != "" {
+		cfg.HostnameOverride = *flagHostnameOverride
+	}
+	if *flagHTTPPath != "" {
+		cfg.HTTPPath = *flagHTTPPath
+	}
+	if *flagHTTPAuthMode != "" {
+		cfg.HTTPAuthMode = *flagHTTPAuthMode
+	}
+	if *flagHTTPHeaders != "" {
+		cfg.HTTPHeaders = parseHeaders(*flagHTTPHeaders)
+	}
+	if *flagBufferCapacity > 0 {
+		cfg.BufferCapacity = *flagBufferCapacity
+	}
+	if *flagBufferPath != "" {
+		cfg.BufferPath = *flagBufferPath
+	}
+	if *flagBufferWALMaxBytes > 0 {
+		cfg.BufferWALMaxBytes = *flagBufferWALMaxBytes
+	}
+	if *flagSendBatchSize > 0 {
+		cfg.SendBatchSize = *flagSendBatchSize
+	}
+	if *flagPromListen != "" {
+		cfg.PromListen = *flagPromListen
+	}
+	cfg.PrintConfig = *flagPrintConfig
+
+	validateSinkConfig(&cfg)
+
+	return cfg, nil
+}
+
+// validateSinkConfig fängt nicht-positive Werte ab, die unabhängig von ihrer
+// Herkunft (Datei, Umgebung oder Flag) den Retry-Pfad lahmlegen würden: ein
+// SendBatchSize von 0 lässt popBatch immer ein leeres Batch liefern, so dass
+// senderLoop nie mehr etwas abräumt, ein negativer lässt popBatch paniken;
+// ein BufferCapacity von 0 lässt den ringBuffer jede Probe sofort verwerfen,
+// ein negativer lässt newRingBuffer paniken.
+func validateSinkConfig(cfg *Config) {
+	if cfg.SendBatchSize <= 0 {
+		log.Printf("invalid SendBatchSize=%d, using default %d", cfg.SendBatchSize, defaultSendBatchSize)
+		cfg.SendBatchSize = defaultSendBatchSize
+	}
+	if cfg.BufferCapacity <= 0 {
+		log.Printf("invalid BufferCapacity=%d, using default %d", cfg.BufferCapacity, defaultBufferCapacity)
+		cfg.BufferCapacity = defaultBufferCapacity
+	}
+}
+
+// loadFileConfig liest und parst eine YAML- oder TOML-Config-Datei anhand
+// ihrer Dateiendung.
+func loadFileConfig(path string) (fileConfig, error) {
+	var fc fileConfig
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fc, err
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &fc)
+	case ".toml":
+		_, err = toml.Decode(string(data), &fc)
+	default:
+		err = fmt.Errorf("unsupported config extension %q (use .yaml, .yml or .toml)", ext)
+	}
+	return fc, err
+}
+
+func applyFileConfig(cfg *Config, fc fileConfig) {
+	if fc.APIURL != nil {
+		cfg.APIURL = *fc.APIURL
+	}
+	if fc.APISecret != nil {
+		cfg.APISecret = *fc.APISecret
+	}
+	if fc.CollectIntervalS != nil {
+		cfg.CollectInterval = time.Duration(*fc.CollectIntervalS) * time.Second
+	}
+	if fc.RequestTimeoutS != nil {
+		cfg.RequestTimeout = time.Duration(*fc.RequestTimeoutS) * time.Second
+	}
+	if len(fc.ThermalZones) > 0 {
+		cfg.ThermalZones = fc.ThermalZones
+	}
+	if len(fc.EnabledCollectors) > 0 {
+		cfg.EnabledCollectors = fc.EnabledCollectors
+	}
+	if len(fc.Sinks) > 0 {
+		cfg.Sinks = fc.Sinks
+	}
+	if fc.HostnameOverride != nil {
+		cfg.HostnameOverride = *fc.HostnameOverride
+	}
+	if len(fc.Labels) > 0 {
+		cfg.Labels = fc.Labels
+	}
+	if fc.HTTPPath != nil {
+		cfg.HTTPPath = *fc.HTTPPath
+	}
+	if fc.HTTPAuthMode != nil {
+		cfg.HTTPAuthMode = *fc.HTTPAuthMode
+	}
+	if len(fc.HTTPHeaders) > 0 {
+		cfg.HTTPHeaders = fc.HTTPHeaders
+	}
+	if fc.BufferCapacity != nil {
+		cfg.BufferCapacity = *fc.BufferCapacity
+	}
+	if fc.BufferPath != nil {
+		cfg.BufferPath = *fc.BufferPath
+	}
+	if fc.BufferWALMaxBytes != nil {
+		cfg.BufferWALMaxBytes = *fc.BufferWALMaxBytes
+	}
+	if fc.SendBatchSize != nil {
+		cfg.SendBatchSize = *fc.SendBatchSize
+	}
+	if fc.PromListen != nil {
+		cfg.PromListen = *fc.PromListen
+	}
+}
+
+func applyEnv(cfg *Config) {
+	if v := os.Getenv("API_URL"); v != "" {
+		cfg.APIURL = v
+	}
+	if v := os.Getenv("API_SECRET"); v != "" {
+		cfg.APISecret = v
+	}
+	cfg.CollectInterval = envDurationSeconds("COLLECT_INTERVAL", cfg.CollectInterval)
+	cfg.RequestTimeout = envDurationSeconds("REQUEST_TIMEOUT", cfg.RequestTimeout)
+	if v := os.Getenv("THERMAL_ZONES"); v != "" {
+		cfg.ThermalZones = splitAndTrim(v)
+	}
+	if v := os.Getenv("ENABLED_COLLECTORS"); v != "" {
+		cfg.EnabledCollectors = splitAndTrim(v)
+	}
+	if v := os.Getenv("SINKS"); v != "" {
+		cfg.Sinks = splitAndTrim(v)
+	}
+	if v := os.Getenv("HOSTNAME_OVERRIDE"); v != "" {
+		cfg.HostnameOverride = v
+	}
+	if v := os.Getenv("LABELS"); v != "" {
+		cfg.Labels = parseHeaders(v)
+	}
+	if v := os.Getenv("HTTP_PATH"); v != "" {
+		cfg.HTTPPath = v
+	}
+	if v := os.Getenv("HTTP_AUTH_MODE"); v != "" {
+		cfg.HTTPAuthMode = v
+	}
+	if v := os.Getenv("HTTP_HEADERS"); v != "" {
+		cfg.HTTPHeaders = parseHeaders(v)
+	}
+	cfg.BufferCapacity = envInt("BUFFER_CAPACITY", cfg.BufferCapacity)
+	if v := os.Getenv("BUFFER_PATH"); v != "" {
+		cfg.BufferPath = v
+	}
+	cfg.BufferWALMaxBytes = int64(envInt("BUFFER_WAL_MAX_BYTES", int(cfg.BufferWALMaxBytes)))
+	cfg.SendBatchSize = envInt("SEND_BATCH_SIZE", cfg.SendBatchSize)
+	if v := os.Getenv("PROM_LISTEN"); v != "" {
+		cfg.PromListen = v
+	}
+}
+
+func splitAndTrim(raw string) []string {
+	var out []string
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// printConfig gibt die aufgelöste Konfiguration als eingerücktes JSON auf
+// stdout aus, zum Debuggen mit --print-config. APISecret wird dabei
+// redigiert, damit niemand aus Versehen ein Secret in ein Issue oder Log
+// einfügt.
+func printConfig(cfg Config) {
+	redacted := cfg
+	if redacted.APISecret != "" {
+		redacted.APISecret = "<redacted>"
+	}
+
+	out, err := json.MarshalIndent(redacted, "", "  ")
+	if err != nil {
+		log.Fatalf("Error marshaling config: %v", err)
+	}
+	fmt.Println(string(out))
+}
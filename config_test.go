@@ -0,0 +1,102 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func clearAgentEnv(t *testing.T) {
+	t.Helper()
+	vars := []string{
+		"AGENT_CONFIG", "API_URL", "API_SECRET", "COLLECT_INTERVAL", "REQUEST_TIMEOUT",
+		"THERMAL_ZONES", "ENABLED_COLLECTORS", "SINKS", "HOSTNAME_OVERRIDE", "LABELS",
+		"HTTP_PATH", "HTTP_AUTH_MODE", "HTTP_HEADERS", "BUFFER_CAPACITY", "BUFFER_PATH",
+		"BUFFER_WAL_MAX_BYTES", "SEND_BATCH_SIZE", "PROM_LISTEN",
+	}
+	for _, v := range vars {
+		os.Unsetenv(v)
+	}
+}
+
+func TestLoadConfigDefaults(t *testing.T) {
+	clearAgentEnv(t)
+
+	cfg, err := loadConfig(nil)
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+
+	if cfg.BufferCapacity != 1024 {
+		t.Errorf("BufferCapacity = %d, want 1024", cfg.BufferCapacity)
+	}
+	if cfg.SendBatchSize != 20 {
+		t.Errorf("SendBatchSize = %d, want 20", cfg.SendBatchSize)
+	}
+	if cfg.PromListen != ":9256" {
+		t.Errorf("PromListen = %q, want :9256", cfg.PromListen)
+	}
+}
+
+func TestLoadConfigEnvOverridesFile(t *testing.T) {
+	clearAgentEnv(t)
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "agent.yaml")
+	yaml := "send_batch_size: 5\nbuffer_capacity: 50\n"
+	if err := os.WriteFile(configPath, []byte(yaml), 0o644); err != nil {
+		t.Fatalf("write config file: %v", err)
+	}
+
+	os.Setenv("SEND_BATCH_SIZE", "7")
+	defer os.Unsetenv("SEND_BATCH_SIZE")
+
+	cfg, err := loadConfig([]string{"--config", configPath})
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+
+	if cfg.BufferCapacity != 50 {
+		t.Errorf("BufferCapacity = %d, want 50 from file", cfg.BufferCapacity)
+	}
+	if cfg.SendBatchSize != 7 {
+		t.Errorf("SendBatchSize = %d, want 7 from env (overrides file)", cfg.SendBatchSize)
+	}
+}
+
+func TestLoadConfigFlagsOverrideEnv(t *testing.T) {
+	clearAgentEnv(t)
+
+	os.Setenv("SEND_BATCH_SIZE", "7")
+	defer os.Unsetenv("SEND_BATCH_SIZE")
+
+	cfg, err := loadConfig([]string{"--send-batch-size", "42"})
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+
+	if cfg.SendBatchSize != 42 {
+		t.Errorf("SendBatchSize = %d, want 42 from flag (overrides env)", cfg.SendBatchSize)
+	}
+}
+
+func TestLoadConfigRejectsNonPositiveSendBatchSizeAndBufferCapacity(t *testing.T) {
+	clearAgentEnv(t)
+
+	os.Setenv("SEND_BATCH_SIZE", "0")
+	os.Setenv("BUFFER_CAPACITY", "-5")
+	defer os.Unsetenv("SEND_BATCH_SIZE")
+	defer os.Unsetenv("BUFFER_CAPACITY")
+
+	cfg, err := loadConfig(nil)
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+
+	if cfg.SendBatchSize != defaultSendBatchSize {
+		t.Errorf("SendBatchSize = %d, want fallback to default %d", cfg.SendBatchSize, defaultSendBatchSize)
+	}
+	if cfg.BufferCapacity != defaultBufferCapacity {
+		t.Errorf("BufferCapacity = %d, want fallback to default %d", cfg.BufferCapacity, defaultBufferCapacity)
+	}
+}
@@ -1,38 +1,127 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
-	"github.com/joho/godotenv"
 	"github.com/shirou/gopsutil/v3/cpu"
 	"github.com/shirou/gopsutil/v3/mem"
 )
 
-// SystemStats repräsentiert die gesammelten Systemstatistiken.
+// SystemStats repräsentiert die gesammelten Systemstatistiken. Die Basisfelder
+// (cpu_percent, ram_*, temperature_c) bleiben aus Kompatibilitätsgründen zur
+// bestehenden API erhalten; zusätzliche Collectors hängen ihre Ausgabe unter
+// ihrem Namen in Collectors ein.
 type SystemStats struct {
-	CPUPercent     float64 `json:"cpu_percent"`
-	RAMUsedGB      float64 `json:"ram_used_gb"`
-	RAMUsedPercent float64 `json:"ram_used_percent"`
-	TemperatureC   float64 `json:"temperature_c"`
+	Hostname       string            `json:"hostname,omitempty"`
+	CPUPercent     float64           `json:"cpu_percent"`
+	RAMUsedGB      float64           `json:"ram_used_gb"`
+	RAMUsedPercent float64           `json:"ram_used_percent"`
+	TemperatureC   float64           `json:"temperature_c"`
+	Collectors     map[string]any    `json:"collectors,omitempty"`
+	Labels         map[string]string `json:"labels,omitempty"`
 }
 
-func getCPUUsage() (float64, error) {
-	percentages, err := cpu.Percent(time.Second, false)
+// defaultCollectorNames wird verwendet, wenn kein anderer Wert für
+// ENABLED_COLLECTORS konfiguriert ist.
+var defaultCollectorNames = []string{"cpu", "disk", "network", "load", "thermal"}
+
+// buildCollectors instanziiert die Collectors, die in names referenziert
+// werden. thermalZones schränkt den thermal-Collector auf bestimmte Zonen
+// ein (leer = alle). Unbekannte Namen werden übersprungen und geloggt statt
+// das Programm zu stoppen.
+func buildCollectors(names, thermalZones []string) []Collector {
+	collectors := make([]Collector, 0, len(names))
+	for _, name := range names {
+		switch name {
+		case "cpu":
+			collectors = append(collectors, newCPUCollector())
+		case "disk":
+			collectors = append(collectors, newDiskCollector())
+		case "network":
+			collectors = append(collectors, newNetCollector())
+		case "load":
+			collectors = append(collectors, newLoadCollector())
+		case "thermal":
+			collectors = append(collectors, newThermalCollector(thermalZones))
+		default:
+			log.Printf("unknown collector %q, skipping", name)
+		}
+	}
+	return collectors
+}
+
+// envDurationSeconds liest key als Ganzzahl-Sekunden aus der Umgebung, oder
+// gibt def zurück, falls key fehlt oder nicht parsbar ist.
+func envDurationSeconds(key string, def time.Duration) time.Duration {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+
+	seconds, err := strconv.Atoi(raw)
+	if err != nil || seconds <= 0 {
+		log.Printf("invalid %s=%q, using default %s", key, raw, def)
+		return def
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// envInt liest key als Ganzzahl aus der Umgebung, oder gibt def zurück,
+// falls key fehlt oder nicht parsbar ist.
+func envInt(key string, def int) int {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		log.Printf("invalid %s=%q, using default %d", key, raw, def)
+		return def
+	}
+	return value
+}
+
+// newHTTPClient baut den einmalig zu erstellenden Client für den HTTP-Sink.
+// Die Transport-Timeouts verhindern, dass ein hängender Verbindungsaufbau den
+// Collect-Tick blockiert.
+func newHTTPClient() *http.Client {
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+			IdleConnTimeout:     90 * time.Second,
+			MaxIdleConnsPerHost: 4,
+		},
+	}
+}
+
+// getCPUUsage liefert die aggregierte CPU-Auslastung seit dem letzten Aufruf
+// (interval 0), genau wie cpuCollector.Collect, statt wie früher einen
+// eigenen blockierenden 1-Sekunden-Sample zu erzwingen.
+func getCPUUsage(ctx context.Context) (float64, error) {
+	percentages, err := cpu.PercentWithContext(ctx, 0, false)
 	if err != nil {
 		return 0, err
 	}
 	return percentages[0], nil
 }
 
-func getRAMUsage() (float64, float64, error) {
-	memory, err := mem.VirtualMemory()
+func getRAMUsage(ctx context.Context) (float64, float64, error) {
+	memory, err := mem.VirtualMemoryWithContext(ctx)
 	if err != nil {
 		return 0, 0, err
 	}
@@ -40,7 +129,11 @@ func getRAMUsage() (float64, float64, error) {
 	return usedGB, memory.UsedPercent, nil
 }
 
-func getTemperature() (float64, error) {
+func getTemperature(ctx context.Context) (float64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
 	data, err := ioutil.ReadFile("/sys/class/thermal/thermal_zone0/temp")
 	if err != nil {
 		return 0, fmt.Errorf("Temperaturdatei nicht gefunden: %v", err)
@@ -61,79 +154,169 @@ func parseFloat(s string) (float64, error) {
 	return f, err
 }
 
-func sendDataToAPI(apiURL, apiSecret string, stats SystemStats) error {
-	jsonData, err := json.Marshal(stats)
+func main() {
+	cfg, err := loadConfig(os.Args[1:])
 	if err != nil {
-		return fmt.Errorf("JSON error: %v", err)
+		log.Fatalf("Error loading config: %v", err)
 	}
 
-	req, err := http.NewRequest("POST", apiURL+"/api/agent", strings.NewReader(string(jsonData)))
-	if err != nil {
-		return fmt.Errorf("Request error: %v", err)
+	if cfg.PrintConfig {
+		printConfig(cfg)
+		return
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", apiSecret)
+	collectors := buildCollectors(cfg.EnabledCollectors, cfg.ThermalZones)
+
+	hostname := cfg.HostnameOverride
+	if hostname == "" {
+		if h, err := os.Hostname(); err == nil {
+			hostname = h
+		}
+	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	sinks, err := buildSinks(ctx, cfg)
 	if err != nil {
-		return fmt.Errorf("API error: %v", err)
+		log.Fatalf("Error setting up sinks: %v", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := ioutil.ReadAll(resp.Body)
-		return fmt.Errorf("API response: %d - %s", resp.StatusCode, string(body))
+	ticker := time.NewTicker(cfg.CollectInterval)
+	defer ticker.Stop()
+
+	runOnce(ctx, sinks, collectors, cfg.RequestTimeout, hostname, cfg.Labels)
+
+	for running := true; running; {
+		select {
+		case <-ctx.Done():
+			log.Println("shutdown signal received, flushing sinks and exiting")
+			running = false
+		case <-ticker.C:
+			runOnce(ctx, sinks, collectors, cfg.RequestTimeout, hostname, cfg.Labels)
+		}
 	}
 
-	return nil
+	for _, s := range sinks {
+		if err := s.Close(); err != nil {
+			log.Printf("%s sink close error: %v", s.Name(), err)
+		}
+	}
 }
 
-func main() {
-	err := godotenv.Load()
-	if err != nil {
-		log.Fatalf("Error laoding env: %v", err)
+// buildSinks instanziiert die in cfg.Sinks referenzierten Sinks. Der
+// http-Sink benötigt cfg.APIURL/cfg.APISecret; fehlen sie, schlägt der
+// Aufruf fehl.
+func buildSinks(ctx context.Context, cfg Config) ([]Sink, error) {
+	sinks := make([]Sink, 0, len(cfg.Sinks))
+	for _, name := range cfg.Sinks {
+		switch name {
+		case "http":
+			if cfg.APIURL == "" || cfg.APISecret == "" {
+				return nil, fmt.Errorf("http sink requires API_URL and API_SECRET")
+			}
+
+			s, err := newHTTPSink(ctx, httpSinkConfig{
+				apiURL:         cfg.APIURL,
+				apiSecret:      cfg.APISecret,
+				path:           cfg.HTTPPath,
+				authMode:       cfg.HTTPAuthMode,
+				headers:        cfg.HTTPHeaders,
+				bufferCapacity: cfg.BufferCapacity,
+				walPath:        cfg.BufferPath,
+				walMaxBytes:    cfg.BufferWALMaxBytes,
+				batchSize:      cfg.SendBatchSize,
+				requestTimeout: cfg.RequestTimeout,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("http sink: %w", err)
+			}
+			sinks = append(sinks, s)
+		case "stdout":
+			sinks = append(sinks, newStdoutSink())
+		case "prom":
+			s, err := newPromSink(cfg.PromListen)
+			if err != nil {
+				return nil, fmt.Errorf("prom sink: %w", err)
+			}
+			sinks = append(sinks, s)
+		default:
+			log.Printf("unknown sink %q, skipping", name)
+		}
 	}
+	return sinks, nil
+}
 
-	apiURL := os.Getenv("API_URL")
-	apiSecret := os.Getenv("API_SECRET")
-	if apiURL == "" || apiSecret == "" {
-		log.Fatal("env ERROR")
+// parseHeaders parst "Key1=Value1,Key2=Value2" zu einer Headers-Map.
+func parseHeaders(raw string) map[string]string {
+	if raw == "" {
+		return nil
 	}
 
-	for {
-		cpuPercent, err := getCPUUsage()
-		if err != nil {
-			log.Printf("CPU error: %v", err)
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(raw, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok {
 			continue
 		}
+		headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return headers
+}
 
-		ramGB, ramPercent, err := getRAMUsage()
-		if err != nil {
-			log.Printf("RAM error: %v", err)
-			continue
-		}
+// runOnce sammelt genau eine SystemStats-Probe und veröffentlicht sie bei
+// allen Sinks. Collector-Läufe und die Sink-Publishes bekommen jeweils ihr
+// eigenes Kind-Context mit requestTimeout, damit eine einzelne hängende
+// Quelle runOnce höchstens requestTimeout lang aufhält. Die Collectors laufen
+// nacheinander, und die Hauptschleife wartet auf runOnce, bevor sie den
+// nächsten Tick verarbeitet.
+func runOnce(ctx context.Context, sinks []Sink, collectors []Collector, requestTimeout time.Duration, hostname string, labels map[string]string) {
+	cpuCtx, cancel := context.WithTimeout(ctx, requestTimeout)
+	cpuPercent, err := getCPUUsage(cpuCtx)
+	cancel()
+	if err != nil {
+		log.Printf("CPU error: %v", err)
+		return
+	}
 
-		tempC, err := getTemperature()
-		if err != nil {
-			log.Printf("Temperature error : %v", err)
-			tempC = 0
-		}
+	ramCtx, cancel := context.WithTimeout(ctx, requestTimeout)
+	ramGB, ramPercent, err := getRAMUsage(ramCtx)
+	cancel()
+	if err != nil {
+		log.Printf("RAM error: %v", err)
+		return
+	}
 
-		stats := SystemStats{
-			CPUPercent:     cpuPercent,
-			RAMUsedGB:      ramGB,
-			RAMUsedPercent: ramPercent,
-			TemperatureC:   tempC,
-		}
+	tempCtx, cancel := context.WithTimeout(ctx, requestTimeout)
+	tempC, err := getTemperature(tempCtx)
+	cancel()
+	if err != nil {
+		log.Printf("Temperature error : %v", err)
+		tempC = 0
+	}
 
-		if err := sendDataToAPI(apiURL, apiSecret, stats); err != nil {
-			log.Printf("Error sending data to API: %v", err)
-		} else {
-			log.Println("Data successfully sent to API")
-		}
+	stats := SystemStats{
+		Hostname:       hostname,
+		CPUPercent:     cpuPercent,
+		RAMUsedGB:      ramGB,
+		RAMUsedPercent: ramPercent,
+		TemperatureC:   tempC,
+		Labels:         labels,
+	}
 
-		time.Sleep(5 * time.Second)
+	if len(collectors) > 0 {
+		stats.Collectors = make(map[string]any, len(collectors))
+		for _, c := range collectors {
+			collectCtx, cancel := context.WithTimeout(ctx, requestTimeout)
+			result, err := c.Collect(collectCtx)
+			cancel()
+			if err != nil {
+				log.Printf("%s collector error: %v", c.Name(), err)
+				continue
+			}
+			stats.Collectors[c.Name()] = result
+		}
 	}
+
+	publishAll(ctx, sinks, stats, requestTimeout)
 }
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	backoffBase = time.Second
+	backoffCap  = 5 * time.Minute
+)
+
+// backoffDelay berechnet die Wartezeit vor dem nächsten Zustellversuch nach
+// attempt aufeinanderfolgenden Fehlschlägen, als "full jitter":
+// sleep = rand.Int63n(min(cap, base<<attempt)) + 1ns, damit die Wartezeit
+// nie 0 ist.
+func backoffDelay(attempt int) time.Duration {
+	delayCap := int64(backoffBase) << attempt
+	if attempt > 62 || delayCap <= 0 || delayCap > int64(backoffCap) {
+		delayCap = int64(backoffCap)
+	}
+	return time.Duration(rand.Int63n(delayCap) + 1)
+}
+
+// sendBatchToAPI postet eine Batch zuvor gepufferter Proben als JSON-Array,
+// über denselben path/headers/authMode wie der direkte Publish-Pfad in
+// sink_http.go.
+func sendBatchToAPI(ctx context.Context, client *http.Client, cfg httpSinkConfig, batch []SystemStats) error {
+	jsonData, err := json.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("JSON error: %v", err)
+	}
+
+	path := cfg.path
+	if path == "" {
+		path = "/api/agent"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", cfg.apiURL+path, strings.NewReader(string(jsonData)))
+	if err != nil {
+		return fmt.Errorf("Request error: %v", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range cfg.headers {
+		req.Header.Set(k, v)
+	}
+	applyAuth(req, cfg.authMode, cfg.apiSecret, jsonData)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("API error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("API response: %d - %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
+
+// senderLoop drainiert rb in FIFO-Reihenfolge, gebündelt zu bis zu batchSize
+// Proben pro POST, und wiederholt fehlgeschlagene Batches mit exponentiellem
+// Backoff und vollem Jitter. Bei ctx.Done() wird der restliche Inhalt von rb
+// in die WAL geschrieben, bevor die Schleife beendet wird.
+func senderLoop(ctx context.Context, rb *ringBuffer, w *wal, client *http.Client, cfg httpSinkConfig, done chan<- struct{}) {
+	defer close(done)
+
+	attempt := 0
+	for {
+		select {
+		case <-ctx.Done():
+			if remaining := rb.drain(); len(remaining) > 0 {
+				if err := w.append(remaining); err != nil {
+					log.Printf("failed to flush buffer to WAL on shutdown: %v", err)
+				} else {
+					log.Printf("flushed %d buffered samples to WAL", len(remaining))
+				}
+			}
+			return
+		default:
+		}
+
+		batch := rb.popBatch(cfg.batchSize)
+		if len(batch) == 0 {
+			select {
+			case <-ctx.Done():
+				continue
+			case <-time.After(200 * time.Millisecond):
+				continue
+			}
+		}
+
+		sendCtx, cancel := context.WithTimeout(ctx, cfg.requestTimeout)
+		err := sendBatchToAPI(sendCtx, client, cfg, batch)
+		cancel()
+
+		if err != nil {
+			log.Printf("buffered send failed (attempt %d, %d samples): %v", attempt+1, len(batch), err)
+			rb.pushFront(batch)
+
+			wait := backoffDelay(attempt)
+			attempt++
+			select {
+			case <-ctx.Done():
+			case <-time.After(wait):
+			}
+			continue
+		}
+
+		attempt = 0
+	}
+}
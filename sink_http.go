@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// httpSinkConfig bündelt die Umgebungsvariablen des HTTP-Sinks.
+type httpSinkConfig struct {
+	apiURL         string
+	apiSecret      string
+	path           string
+	authMode       string // "raw" (default, bisheriges Verhalten), "bearer" oder "hmac"
+	headers        map[string]string
+	bufferCapacity int
+	walPath        string
+	walMaxBytes    int64
+	batchSize      int
+	requestTimeout time.Duration
+}
+
+// httpSink ist der bisherige JSON-POST-Pfad, jetzt hinter dem Sink-Interface:
+// ein direkter Publish-Versuch, der bei Fehlschlag in einen ringBuffer fällt
+// und von einer Hintergrund-senderLoop mit Backoff nachgeliefert wird.
+type httpSink struct {
+	cfg    httpSinkConfig
+	client *http.Client
+	rb     *ringBuffer
+	wal    *wal
+	done   chan struct{}
+}
+
+// newHTTPSink baut den Sink, spielt eine vorhandene WAL ein und startet die
+// Hintergrund-senderLoop, die an ctx gebunden ist (endet, wenn ctx abgebrochen wird).
+func newHTTPSink(ctx context.Context, cfg httpSinkConfig) (*httpSink, error) {
+	rb := newRingBuffer(cfg.bufferCapacity)
+
+	w, err := openWAL(cfg.walPath, cfg.walMaxBytes)
+	if err != nil {
+		return nil, fmt.Errorf("open WAL: %w", err)
+	}
+	if replayed, err := w.replay(); err != nil {
+		log.Printf("Error replaying WAL: %v", err)
+	} else if len(replayed) > 0 {
+		log.Printf("replaying %d buffered samples from WAL", len(replayed))
+		for _, stats := range replayed {
+			rb.push(stats)
+		}
+	}
+
+	s := &httpSink{
+		cfg:    cfg,
+		client: newHTTPClient(),
+		rb:     rb,
+		wal:    w,
+		done:   make(chan struct{}),
+	}
+
+	go senderLoop(ctx, rb, w, s.client, cfg, s.done)
+
+	return s, nil
+}
+
+func (s *httpSink) Name() string { return "http" }
+
+func (s *httpSink) Publish(ctx context.Context, stats SystemStats) error {
+	if err := s.postOne(ctx, stats); err != nil {
+		log.Printf("http sink: direct send failed, buffering for retry: %v", err)
+		if dropped := s.rb.push(stats); dropped {
+			log.Println("http sink: buffer full, dropped oldest sample")
+		}
+		return err
+	}
+	return nil
+}
+
+// postOne sendet eine einzelne Probe direkt, ohne den ringBuffer zu berühren.
+func (s *httpSink) postOne(ctx context.Context, stats SystemStats) error {
+	jsonData, err := json.Marshal(stats)
+	if err != nil {
+		return fmt.Errorf("JSON error: %v", err)
+	}
+	return s.doPost(ctx, jsonData)
+}
+
+func (s *httpSink) doPost(ctx context.Context, body []byte) error {
+	path := s.cfg.path
+	if path == "" {
+		path = "/api/agent"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.cfg.apiURL+path, strings.NewReader(string(body)))
+	if err != nil {
+		return fmt.Errorf("Request error: %v", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.cfg.headers {
+		req.Header.Set(k, v)
+	}
+	applyAuth(req, s.cfg.authMode, s.cfg.apiSecret, body)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("API error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("API response: %d - %s", resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
+
+// applyAuth setzt den Authorization-Header passend zu mode:
+//   - "raw" (Standard): Authorization: <secret>, wie bisher
+//   - "bearer": Authorization: Bearer <secret>
+//   - "hmac": X-Signature: hex(HMAC-SHA256(body, secret))
+func applyAuth(req *http.Request, mode, secret string, body []byte) {
+	switch mode {
+	case "bearer":
+		req.Header.Set("Authorization", "Bearer "+secret)
+	case "hmac":
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		req.Header.Set("X-Signature", hex.EncodeToString(mac.Sum(nil)))
+	default:
+		req.Header.Set("Authorization", secret)
+	}
+}
+
+func (s *httpSink) Close() error {
+	<-s.done
+	return s.wal.close()
+}
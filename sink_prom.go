@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// promSink hält die zuletzt veröffentlichte Probe vor und exponiert sie unter
+// /metrics im Prometheus-Text-Format, statt wie die anderen Sinks aktiv
+// auszuliefern (Prometheus scraped selbst).
+type promSink struct {
+	mu     sync.RWMutex
+	latest SystemStats
+	have   bool
+
+	server *http.Server
+}
+
+func newPromSink(listen string) (*promSink, error) {
+	s := &promSink{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", s.handleMetrics)
+
+	s.server = &http.Server{Addr: listen, Handler: mux}
+
+	ln, err := net.Listen("tcp", listen)
+	if err != nil {
+		return nil, fmt.Errorf("prom sink listen: %w", err)
+	}
+
+	go func() {
+		if err := s.server.Serve(ln); err != nil && err != http.ErrServerClosed {
+			log.Printf("prom sink server error: %v", err)
+		}
+	}()
+
+	return s, nil
+}
+
+func (s *promSink) Name() string { return "prom" }
+
+func (s *promSink) Publish(ctx context.Context, stats SystemStats) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latest = stats
+	s.have = true
+	return nil
+}
+
+func (s *promSink) Close() error {
+	return s.server.Close()
+}
+
+func (s *promSink) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	stats := s.latest
+	have := s.have
+	s.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	if !have {
+		return
+	}
+	writeMetrics(w, stats)
+}
+
+// writeMetrics rendert stats als Prometheus-Text-Exposition.
+func writeMetrics(w io.Writer, stats SystemStats) {
+	fmt.Fprintln(w, "# HELP agent_cpu_percent Aggregate CPU usage in percent.")
+	fmt.Fprintln(w, "# TYPE agent_cpu_percent gauge")
+	fmt.Fprintf(w, "agent_cpu_percent %f\n", stats.CPUPercent)
+
+	fmt.Fprintln(w, "# HELP agent_ram_used_bytes Used RAM in bytes.")
+	fmt.Fprintln(w, "# TYPE agent_ram_used_bytes gauge")
+	fmt.Fprintf(w, "agent_ram_used_bytes %f\n", stats.RAMUsedGB*1e9)
+
+	fmt.Fprintln(w, "# HELP agent_ram_used_percent Used RAM in percent.")
+	fmt.Fprintln(w, "# TYPE agent_ram_used_percent gauge")
+	fmt.Fprintf(w, "agent_ram_used_percent %f\n", stats.RAMUsedPercent)
+
+	fmt.Fprintln(w, "# HELP agent_temperature_celsius Thermal zone temperature in Celsius.")
+	fmt.Fprintln(w, "# TYPE agent_temperature_celsius gauge")
+	if thermal, ok := stats.Collectors["thermal"].([]ThermalZoneStats); ok && len(thermal) > 0 {
+		for _, zone := range thermal {
+			fmt.Fprintf(w, "agent_temperature_celsius{zone=%q,type=%q} %f\n", zone.Zone, zone.Type, zone.CelsiusValue)
+		}
+	} else {
+		fmt.Fprintf(w, "agent_temperature_celsius{zone=\"thermal_zone0\"} %f\n", stats.TemperatureC)
+	}
+
+	if cpuStats, ok := stats.Collectors["cpu"].(CPUStats); ok {
+		fmt.Fprintln(w, "# HELP agent_cpu_core_percent Per-core CPU usage in percent.")
+		fmt.Fprintln(w, "# TYPE agent_cpu_core_percent gauge")
+		for i, pct := range cpuStats.PercentPerCore {
+			fmt.Fprintf(w, "agent_cpu_core_percent{core=\"%d\"} %f\n", i, pct)
+		}
+	}
+
+	if disks, ok := stats.Collectors["disk"].([]DiskStats); ok {
+		fmt.Fprintln(w, "# HELP agent_disk_used_percent Disk usage in percent per mount.")
+		fmt.Fprintln(w, "# TYPE agent_disk_used_percent gauge")
+		for _, d := range disks {
+			fmt.Fprintf(w, "agent_disk_used_percent{device=%q,mountpoint=%q,fstype=%q} %f\n",
+				d.Device, d.Mountpoint, d.Fstype, d.UsedPercent)
+		}
+	}
+
+	if nets, ok := stats.Collectors["network"].([]NetStats); ok {
+		fmt.Fprintln(w, "# HELP agent_network_bytes_total Cumulative bytes sent/received per interface.")
+		fmt.Fprintln(w, "# TYPE agent_network_bytes_total counter")
+		for _, n := range nets {
+			fmt.Fprintf(w, "agent_network_bytes_total{interface=%q,direction=\"sent\"} %d\n", n.Interface, n.BytesSent)
+			fmt.Fprintf(w, "agent_network_bytes_total{interface=%q,direction=\"recv\"} %d\n", n.Interface, n.BytesRecv)
+		}
+	}
+
+	if loadStats, ok := stats.Collectors["load"].(LoadStats); ok {
+		fmt.Fprintln(w, "# HELP agent_load Load average.")
+		fmt.Fprintln(w, "# TYPE agent_load gauge")
+		fmt.Fprintf(w, "agent_load{period=\"1m\"} %f\n", loadStats.Load1)
+		fmt.Fprintf(w, "agent_load{period=\"5m\"} %f\n", loadStats.Load5)
+		fmt.Fprintf(w, "agent_load{period=\"15m\"} %f\n", loadStats.Load15)
+	}
+}
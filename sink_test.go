@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestApplyAuthRaw(t *testing.T) {
+	req, _ := http.NewRequest("POST", "http://example.invalid", nil)
+	applyAuth(req, "raw", "s3cr3t", []byte("body"))
+
+	if got := req.Header.Get("Authorization"); got != "s3cr3t" {
+		t.Errorf("Authorization = %q, want raw secret", got)
+	}
+	if got := req.Header.Get("X-Signature"); got != "" {
+		t.Errorf("X-Signature should be unset in raw mode, got %q", got)
+	}
+}
+
+func TestApplyAuthDefaultModeIsRaw(t *testing.T) {
+	req, _ := http.NewRequest("POST", "http://example.invalid", nil)
+	applyAuth(req, "", "s3cr3t", []byte("body"))
+
+	if got := req.Header.Get("Authorization"); got != "s3cr3t" {
+		t.Errorf("Authorization = %q, want raw secret for empty mode", got)
+	}
+}
+
+func TestApplyAuthBearer(t *testing.T) {
+	req, _ := http.NewRequest("POST", "http://example.invalid", nil)
+	applyAuth(req, "bearer", "s3cr3t", []byte("body"))
+
+	if got := req.Header.Get("Authorization"); got != "Bearer s3cr3t" {
+		t.Errorf("Authorization = %q, want %q", got, "Bearer s3cr3t")
+	}
+}
+
+func TestApplyAuthHMAC(t *testing.T) {
+	req, _ := http.NewRequest("POST", "http://example.invalid", nil)
+	body := []byte(`{"hostname":"x"}`)
+	secret := "s3cr3t"
+	applyAuth(req, "hmac", secret, body)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	want := hex.EncodeToString(mac.Sum(nil))
+
+	if got := req.Header.Get("X-Signature"); got != want {
+		t.Errorf("X-Signature = %q, want %q", got, want)
+	}
+	if got := req.Header.Get("Authorization"); got != "" {
+		t.Errorf("Authorization should be unset in hmac mode, got %q", got)
+	}
+}
+
+func TestWriteMetricsBaseFields(t *testing.T) {
+	var buf bytes.Buffer
+	writeMetrics(&buf, SystemStats{CPUPercent: 12.5, RAMUsedGB: 2, RAMUsedPercent: 40, TemperatureC: 55})
+	out := buf.String()
+
+	for _, want := range []string{
+		"# HELP agent_cpu_percent",
+		"# TYPE agent_cpu_percent gauge",
+		"agent_cpu_percent 12.500000",
+		"agent_temperature_celsius{zone=\"thermal_zone0\"} 55.000000",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestWriteMetricsDiskAndThermalCollectors(t *testing.T) {
+	var buf bytes.Buffer
+	stats := SystemStats{
+		Collectors: map[string]any{
+			"cpu": CPUStats{PercentPerCore: []float64{1, 2}},
+			"disk": []DiskStats{
+				{Device: "/dev/sda1", Mountpoint: "/", Fstype: "ext4", UsedPercent: 80},
+			},
+			"thermal": []ThermalZoneStats{
+				{Zone: "thermal_zone1", Type: "cpu-thermal", CelsiusValue: 61.5},
+			},
+		},
+	}
+	writeMetrics(&buf, stats)
+	out := buf.String()
+
+	for _, want := range []string{
+		`agent_cpu_core_percent{core="0"} 1.000000`,
+		`agent_cpu_core_percent{core="1"} 2.000000`,
+		`agent_disk_used_percent{device="/dev/sda1",mountpoint="/",fstype="ext4"} 80.000000`,
+		`agent_temperature_celsius{zone="thermal_zone1",type="cpu-thermal"} 61.500000`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q, got:\n%s", want, out)
+		}
+	}
+}
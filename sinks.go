@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Sink nimmt eine einzelne SystemStats-Probe entgegen und veröffentlicht sie
+// auf einem bestimmten Kanal (HTTP-POST, stdout, Prometheus-Scrape, ...).
+// Ein Fehler in einem Sink darf die anderen nicht blockieren; siehe publishAll.
+type Sink interface {
+	Name() string
+	Publish(ctx context.Context, stats SystemStats) error
+	Close() error
+}
+
+// defaultSinkNames wird verwendet, wenn kein anderer Wert für SINKS
+// konfiguriert ist, und erhält das bisherige Verhalten (reiner HTTP-POST) bei.
+var defaultSinkNames = []string{"http"}
+
+// publishAll veröffentlicht stats bei allen sinks gleichzeitig. Jeder Sink
+// bekommt sein eigenes Kind-Context mit timeout; ein fehlschlagender Sink wird
+// geloggt, hält die übrigen aber nicht auf.
+func publishAll(ctx context.Context, sinks []Sink, stats SystemStats, timeout time.Duration) {
+	var wg sync.WaitGroup
+	for _, s := range sinks {
+		wg.Add(1)
+		go func(s Sink) {
+			defer wg.Done()
+
+			sinkCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			if err := s.Publish(sinkCtx, stats); err != nil {
+				log.Printf("%s sink publish error: %v", s.Name(), err)
+			}
+		}(s)
+	}
+	wg.Wait()
+}
+
+// stdoutSink schreibt jede Probe als eine Zeile NDJSON, z.B. zum Einsammeln
+// durch einen Container-Log-Collector.
+type stdoutSink struct {
+	mu  sync.Mutex
+	out *os.File
+}
+
+func newStdoutSink() *stdoutSink {
+	return &stdoutSink{out: os.Stdout}
+}
+
+func (s *stdoutSink) Name() string { return "stdout" }
+
+func (s *stdoutSink) Publish(ctx context.Context, stats SystemStats) error {
+	line, err := json.Marshal(stats)
+	if err != nil {
+		return fmt.Errorf("marshal stats: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = fmt.Fprintf(s.out, "%s\n", line)
+	return err
+}
+
+func (s *stdoutSink) Close() error { return nil }